@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/vicanso/elton"
+	"github.com/vicanso/hes"
+)
+
+// staticAuthConfig restricts Prefixes to requests bearing a valid HS256
+// JWT. Secrets supports rotation: every secret is tried on verification,
+// the first one is used to sign new tokens.
+type staticAuthConfig struct {
+	Prefixes []string
+	Secrets  []string
+}
+
+// staticAuthClaims is the payload expected on protected-path tokens: Path
+// scopes the token to a URL prefix, IP optionally pins it to the caller.
+type staticAuthClaims struct {
+	Path string `json:"path"`
+	IP   string `json:"ip,omitempty"`
+	jwt.RegisteredClaims
+}
+
+func staticAuthConfigFromEnv() staticAuthConfig {
+	var prefixes []string
+	if v := os.Getenv("STATIC_PROTECTED"); v != "" {
+		prefixes = strings.Split(v, ",")
+	}
+	var secrets []string
+	if v := os.Getenv("STATIC_JWT_SECRET"); v != "" {
+		secrets = strings.Split(v, ",")
+	}
+	return staticAuthConfig{
+		Prefixes: prefixes,
+		Secrets:  secrets,
+	}
+}
+
+func (cfg staticAuthConfig) protects(path string) bool {
+	for _, prefix := range cfg.Prefixes {
+		if pathWithinScope(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathWithinScope reports whether requested is scope itself or a descendant
+// of it, unlike a raw strings.HasPrefix check which also matches unrelated
+// siblings that merely share a string prefix (e.g. "/private" matching
+// "/private-leak" or "/private/report.pdf" matching
+// "/private/report.pdfXLEAK").
+func pathWithinScope(requested, scope string) bool {
+	if requested == scope {
+		return true
+	}
+	if !strings.HasSuffix(scope, "/") {
+		scope += "/"
+	}
+	return strings.HasPrefix(requested, scope)
+}
+
+// signStaticToken mints a token scoped to path, valid for ttl, signed with
+// the first (primary) secret.
+func signStaticToken(cfg staticAuthConfig, path string, ttl time.Duration) (string, error) {
+	claims := staticAuthClaims{
+		Path: path,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(cfg.Secrets[0]))
+}
+
+func verifyStaticToken(cfg staticAuthConfig, raw string) (*staticAuthClaims, error) {
+	var lastErr error
+	for _, secret := range cfg.Secrets {
+		claims := &staticAuthClaims{}
+		_, err := jwt.ParseWithClaims(raw, claims, func(*jwt.Token) (interface{}, error) {
+			return []byte(secret), nil
+		})
+		if err == nil {
+			return claims, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// constantTimeEqual compares two strings in time independent of their
+// contents, so guarding the admin token doesn't leak it byte-by-byte
+// through response timing.
+func constantTimeEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func tokenFromRequest(c *elton.Context) string {
+	auth := c.GetRequestHeader("Authorization")
+	if strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return c.QueryParam("token")
+}
+
+// newStaticAuthMiddleware guards cfg.Prefixes behind a JWT whose Path
+// claim must prefix the requested URL and whose exp must be in the
+// future. Matched responses are marked no-cache so the shared LRU store
+// never serves one caller's protected content to another.
+func newStaticAuthMiddleware(cfg staticAuthConfig) elton.Handler {
+	return func(c *elton.Context) error {
+		if !cfg.protects(c.Request.URL.Path) {
+			return c.Next()
+		}
+		c.NoCache()
+
+		raw := tokenFromRequest(c)
+		if raw == "" {
+			return hes.NewWithStatusCode("token is required", http.StatusUnauthorized)
+		}
+		claims, err := verifyStaticToken(cfg, raw)
+		if err != nil {
+			return hes.NewWithStatusCode(err.Error(), http.StatusUnauthorized)
+		}
+		if !pathWithinScope(c.Request.URL.Path, claims.Path) {
+			return hes.NewWithStatusCode("token is not valid for this path", http.StatusForbidden)
+		}
+		if claims.IP != "" && claims.IP != c.RealIP() {
+			return hes.NewWithStatusCode("token is not valid for this client", http.StatusForbidden)
+		}
+		return c.Next()
+	}
+}
+
+// newSignHandler exposes a token-minting endpoint for an upstream service
+// to call, guarded by a separate admin token (never the signing secret
+// itself, so it can be rotated independently).
+func newSignHandler(cfg staticAuthConfig, adminToken string) elton.Handler {
+	return func(c *elton.Context) error {
+		if adminToken == "" || !constantTimeEqual(c.GetRequestHeader("Authorization"), "Bearer "+adminToken) {
+			return hes.NewWithStatusCode("admin token is invalid", http.StatusUnauthorized)
+		}
+		path := c.QueryParam("path")
+		if path == "" {
+			return hes.NewWithStatusCode("path is required", http.StatusBadRequest)
+		}
+		ttl := 10 * time.Minute
+		if v := c.QueryParam("ttl"); v != "" {
+			if d, err := time.ParseDuration(v); err == nil {
+				ttl = d
+			}
+		}
+		token, err := signStaticToken(cfg, path, ttl)
+		if err != nil {
+			return err
+		}
+		buf, err := json.Marshal(map[string]string{
+			"token": token,
+		})
+		if err != nil {
+			return err
+		}
+		c.SetHeader("Content-Type", "application/json; charset=utf-8")
+		c.BodyBuffer = bytes.NewBuffer(buf)
+		return nil
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestPathWithinScope(t *testing.T) {
+	tests := []struct {
+		requested string
+		scope     string
+		want      bool
+	}{
+		{"/private/report.pdf", "/private/report.pdf", true},
+		{"/private/report.pdfXLEAK", "/private/report.pdf", false},
+		{"/private/sub/report.pdf", "/private", true},
+		{"/private-leak", "/private", false},
+		{"/private", "/private", true},
+		{"/public/report.pdf", "/private", false},
+	}
+	for _, tt := range tests {
+		if got := pathWithinScope(tt.requested, tt.scope); got != tt.want {
+			t.Errorf("pathWithinScope(%q, %q) = %v, want %v", tt.requested, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestStaticAuthConfigProtects(t *testing.T) {
+	cfg := staticAuthConfig{Prefixes: []string{"/private", "/downloads"}}
+	if !cfg.protects("/private/report.pdf") {
+		t.Error("expected /private/report.pdf to be protected")
+	}
+	if cfg.protects("/private-leak") {
+		t.Error("expected /private-leak not to be protected by the /private prefix")
+	}
+	if cfg.protects("/public/report.pdf") {
+		t.Error("expected /public/report.pdf not to be protected")
+	}
+}
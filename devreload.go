@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/vicanso/elton"
+)
+
+// liveReloadScript is injected into every HTML response in dev mode so the
+// browser reconnects to the reload SSE endpoint and refreshes on change.
+const liveReloadScript = `<script>(function(){var es=new EventSource("/.__reload");es.onmessage=function(){location.reload()}})()</script>`
+
+const reloadEndpoint = "/.__reload"
+
+// devReloadHub fans out "reload" events to connected SSE clients. Each
+// client gets its own buffered channel so a slow reader can't block the
+// broadcaster.
+type devReloadHub struct {
+	clients sync.Map // chan struct{} -> struct{}
+}
+
+func newDevReloadHub() *devReloadHub {
+	return &devReloadHub{}
+}
+
+func (h *devReloadHub) broadcast() {
+	h.clients.Range(func(key, _ interface{}) bool {
+		ch := key.(chan struct{})
+		select {
+		case ch <- struct{}{}:
+		default:
+			// client is slow, drop the event rather than block
+		}
+		return true
+	})
+}
+
+// handler serves the SSE endpoint that the injected script connects to.
+func (h *devReloadHub) handler(c *elton.Context) error {
+	ch := make(chan struct{}, 1)
+	h.clients.Store(ch, struct{}{})
+	defer h.clients.Delete(ch)
+
+	c.NoCache()
+	c.SetHeader("Content-Type", "text/event-stream")
+	c.SetHeader("Cache-Control", "no-cache")
+	c.SetHeader("Connection", "keep-alive")
+
+	flusher, ok := c.Response.(interface{ Flush() })
+	notify := c.Request.Context().Done()
+	c.BodyBuffer = bytes.NewBuffer(nil)
+	for {
+		select {
+		case <-notify:
+			return nil
+		case <-ch:
+			_, _ = c.Response.Write([]byte("data: reload\n\n"))
+			if ok {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// watchAndBroadcast recursively watches staticPath and broadcasts a reload
+// after a burst of events settles for debounce.
+func watchAndBroadcast(staticPath string, debounce time.Duration, hub *devReloadHub) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Println("dev reload watcher init fail:", err)
+		return
+	}
+	_ = filepath.Walk(staticPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info == nil || !info.IsDir() {
+			return nil
+		}
+		return watcher.Add(path)
+	})
+
+	var mu sync.Mutex
+	var timer *time.Timer
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&fsnotify.Create == fsnotify.Create {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = watcher.Add(event.Name)
+				}
+			}
+			mu.Lock()
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(debounce, hub.broadcast)
+			mu.Unlock()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Println("dev reload watcher error:", err)
+		}
+	}
+}
+
+// injectLiveReload rewrites an HTML body to add the reload script just
+// before the closing </body> tag, falling back to appending it when no
+// </body> tag is present.
+func injectLiveReload(body []byte) []byte {
+	idx := bytes.LastIndex(body, []byte("</body>"))
+	if idx == -1 {
+		return append(body, []byte(liveReloadScript)...)
+	}
+	out := make([]byte, 0, len(body)+len(liveReloadScript))
+	out = append(out, body[:idx]...)
+	out = append(out, []byte(liveReloadScript)...)
+	out = append(out, body[idx:]...)
+	return out
+}
+
+// newDevReloadMiddleware injects the live-reload script into every
+// text/html response.
+func newDevReloadMiddleware() elton.Handler {
+	return func(c *elton.Context) error {
+		err := c.Next()
+		if err != nil {
+			return err
+		}
+		if c.BodyBuffer == nil {
+			return nil
+		}
+		ct := c.GetHeader("Content-Type")
+		if !bytes.Contains([]byte(ct), []byte("text/html")) {
+			return nil
+		}
+		c.BodyBuffer = bytes.NewBuffer(injectLiveReload(c.BodyBuffer.Bytes()))
+		return nil
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strconv"
@@ -28,6 +29,9 @@ func main() {
 		cacheTTL = 10 * time.Minute
 	}
 	disabledLog := os.Getenv("STATIC_DISABLE_LOG") != ""
+	// dev mode trades caching for instant feedback: edits to staticPath are
+	// picked up and pushed to the browser over SSE
+	devMode := os.Getenv("STATIC_DEV") != ""
 	e := elton.New()
 
 	if !disabledLog {
@@ -50,10 +54,42 @@ func main() {
 		}
 	}
 
-	e.Use(middleware.NewCache(middleware.CacheConfig{
-		Store:      middleware.NewPeekLruStore(256),
-		Compressor: compressor,
-	}))
+	var hub *devReloadHub
+	if devMode {
+		hub = newDevReloadHub()
+		go watchAndBroadcast(staticPath, 100*time.Millisecond, hub)
+		e.GET(reloadEndpoint, hub.handler)
+		e.Use(newDevReloadMiddleware())
+	}
+
+	// auth must run before the cache middleware: it decides per-request
+	// whether a response may be cached at all (via NoCache on protected
+	// prefixes), and a cache middleware registered ahead of it would
+	// short-circuit on a hit and never call c.Next(), skipping auth
+	// entirely for every request after the first successful one
+	authConfig := staticAuthConfigFromEnv()
+	if len(authConfig.Prefixes) != 0 && len(authConfig.Secrets) != 0 {
+		e.Use(newStaticAuthMiddleware(authConfig))
+		e.GET("/sign", newSignHandler(authConfig, os.Getenv("STATIC_ADMIN_TOKEN")))
+	}
+
+	headerRules, err := loadSecurityHeaderRules(os.Getenv("STATIC_HEADERS_FILE"))
+	if err != nil {
+		panic(err)
+	}
+	// must run before the cache middleware: it sets its headers after
+	// c.Next() returns so they overwrite whatever a cache hit merges in,
+	// which only works if it wraps (runs outside of) that middleware
+	e.Use(newSecurityHeadersMiddleware(headerRules))
+
+	if !devMode {
+		// wrap the LRU store so a hit is discarded when the file's mtime has
+		// moved on since it was cached, instead of waiting out cacheTTL
+		e.Use(middleware.NewCache(middleware.CacheConfig{
+			Store:      newMtimeCacheStore(middleware.NewPeekLruStore(256), staticPath),
+			Compressor: compressor,
+		}))
+	}
 
 	sf := new(middleware.FS)
 	e.GET("/ping", func(c *elton.Context) error {
@@ -67,11 +103,20 @@ func main() {
 		}
 		c.NoCache()
 		c.SetContentTypeByExt(".html")
+		if devMode {
+			// the dev-reload middleware only rewrites a buffered body, so
+			// buffer index.html here instead of streaming it
+			buf, readErr := io.ReadAll(r)
+			if readErr != nil {
+				return readErr
+			}
+			c.BodyBuffer = bytes.NewBuffer(buf)
+			return nil
+		}
 		c.Body = r
 		return
 	})
-	// static file route
-	e.GET("/*", middleware.NewStaticServe(sf, middleware.StaticServeConfig{
+	staticServeConfig := middleware.StaticServeConfig{
 		Path: staticPath,
 		// 客户端缓存一年
 		MaxAge: 365 * 24 * time.Hour,
@@ -83,12 +128,34 @@ func main() {
 		EnableStrongETag: true,
 		NoCacheRegexp:    regexp.MustCompile(`.html`),
 		IndexFile:        "index.html",
-	}))
+	}
+	if devMode {
+		// always revalidate in dev mode so edits are never served stale
+		staticServeConfig.MaxAge = 0
+		staticServeConfig.SMaxAge = 0
+	}
+	// static file route
+	e.GET("/*", middleware.NewStaticServe(sf, staticServeConfig))
 	msg := fmt.Sprintf("path:%s, compress(level:%d, minLength:%d, contentType:%s)", staticPath, compressLevel, minLength, contentType)
 	log.Println(msg)
-	log.Println("server is running, http://127.0.0.1:3000")
 
-	err := e.ListenAndServe(":3000")
+	if !devMode {
+		warmInterval, _ := time.ParseDuration(os.Getenv("STATIC_WARM_INTERVAL"))
+		if warmInterval == 0 {
+			warmInterval = 5 * time.Minute
+		}
+		startWarmer(e, staticPath, staticServeConfig.DenyDot, warmInterval, defaultWarmConcurrency)
+	}
+
+	if tc := tlsConfigFromEnv(); len(tc.Domains) != 0 {
+		if err := serveTLS(e, tc); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	log.Println("server is running, http://127.0.0.1:3000")
+	err = e.ListenAndServe(":3000")
 	if err != nil {
 		panic(err)
 	}
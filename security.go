@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/vicanso/elton"
+	"gopkg.in/yaml.v3"
+)
+
+const cspNoncePlaceholder = "{{cspNonce}}"
+
+// securityHeaderRule applies Headers to every request whose path matches
+// PathRegexp; the first matching rule wins.
+type securityHeaderRule struct {
+	PathRegexp string            `json:"pathRegexp" yaml:"pathRegexp"`
+	Headers    map[string]string `json:"headers" yaml:"headers"`
+
+	matcher *regexp.Regexp
+}
+
+// defaultSecurityHeaders ships out of the box so a deploy gets a
+// reasonable baseline even without STATIC_HEADERS_FILE.
+func defaultSecurityHeaders() map[string]string {
+	return map[string]string{
+		"Content-Security-Policy":   "default-src 'self'; script-src 'self' 'nonce-" + cspNoncePlaceholder + "'",
+		"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+		"X-Content-Type-Options":    "nosniff",
+		"Permissions-Policy":        "geolocation=(), camera=(), microphone=()",
+	}
+}
+
+// loadSecurityHeaderRules reads STATIC_HEADERS_FILE (YAML or JSON,
+// detected by extension) into a rule table. An empty path yields no
+// rules, leaving only the built-in defaults.
+func loadSecurityHeaderRules(path string) ([]securityHeaderRule, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []securityHeaderRule
+	if strings.HasSuffix(path, ".json") {
+		err = json.Unmarshal(data, &rules)
+	} else {
+		err = yaml.Unmarshal(data, &rules)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		rules[i].matcher, err = regexp.Compile(rules[i].PathRegexp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+func newNonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// newSecurityHeadersMiddleware applies the first matching rule's headers
+// (falling back to defaults), substituting a fresh nonce into both the
+// CSP header and any {{cspNonce}} placeholder in an HTML body. It must run
+// after compression (so it edits the final bytes) and before the cache
+// middleware (so every cached hit still gets its own nonce rewritten). The
+// headers are set after c.Next() returns, not before: on a cache hit the
+// nested cache middleware merges its stored headers in via Header().Add,
+// so setting ours first would leave two Content-Security-Policy lines
+// (two different nonces) instead of one.
+func newSecurityHeadersMiddleware(rules []securityHeaderRule) elton.Handler {
+	defaults := defaultSecurityHeaders()
+	return func(c *elton.Context) error {
+		nonce, err := newNonce()
+		if err != nil {
+			return err
+		}
+
+		headers := defaults
+		for _, rule := range rules {
+			if rule.matcher.MatchString(c.Request.URL.Path) {
+				headers = rule.Headers
+				break
+			}
+		}
+		// a per-request nonce means this response can never be reused from
+		// cache as-is
+		c.NoCache()
+
+		err = c.Next()
+		if err != nil {
+			return err
+		}
+
+		// c.SetHeader replaces rather than appends, so this overwrites
+		// whatever the cache middleware just merged in from a stored hit
+		for name, value := range headers {
+			c.SetHeader(name, strings.ReplaceAll(value, cspNoncePlaceholder, nonce))
+		}
+
+		ct := c.GetHeader("Content-Type")
+		if c.BodyBuffer != nil && strings.Contains(ct, "text/html") {
+			body := bytes.ReplaceAll(c.BodyBuffer.Bytes(), []byte(cspNoncePlaceholder), []byte(nonce))
+			c.BodyBuffer = bytes.NewBuffer(body)
+		}
+		return nil
+	}
+}
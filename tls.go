@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/vicanso/elton"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// tlsConfig is read from STATIC_TLS_* env vars; serveTLS is a no-op when
+// Domains is empty, leaving the caller to fall back to plain HTTP.
+type tlsConfig struct {
+	Domains      []string
+	Email        string
+	CacheDir     string
+	DirectoryURL string
+}
+
+func tlsConfigFromEnv() tlsConfig {
+	var domains []string
+	if v := os.Getenv("STATIC_TLS_DOMAINS"); v != "" {
+		domains = strings.Split(v, ",")
+	}
+	cacheDir := os.Getenv("STATIC_TLS_CACHE_DIR")
+	if cacheDir == "" {
+		cacheDir = "./acme-cache"
+	}
+	return tlsConfig{
+		Domains:      domains,
+		Email:        os.Getenv("STATIC_TLS_EMAIL"),
+		CacheDir:     cacheDir,
+		DirectoryURL: os.Getenv("STATIC_ACME_DIRECTORY"),
+	}
+}
+
+// serveTLS runs the server over HTTPS with certificates managed by
+// autocert, serving the HTTP-01 challenge (and redirecting everything
+// else to HTTPS) on :80. It blocks until the process receives SIGTERM,
+// then drains in-flight requests before returning.
+func serveTLS(e *elton.Elton, cfg tlsConfig) error {
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.Domains...),
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		Email:      cfg.Email,
+	}
+	if cfg.DirectoryURL != "" {
+		m.Client = &acme.Client{DirectoryURL: cfg.DirectoryURL}
+	}
+
+	httpServer := &http.Server{
+		Addr:    ":80",
+		Handler: m.HTTPHandler(nil),
+	}
+	httpsServer := &http.Server{
+		Addr:      ":443",
+		Handler:   e,
+		TLSConfig: m.TLSConfig(),
+	}
+
+	errCh := make(chan error, 2)
+	go func() {
+		log.Println("acme http-01 challenge listening on :80")
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+	go func() {
+		log.Println("server is running, https://127.0.0.1:443")
+		if err := httpsServer.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+	select {
+	case err := <-errCh:
+		return err
+	case <-sigCh:
+		log.Println("received shutdown signal, draining connections")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_ = httpServer.Shutdown(ctx)
+	return httpsServer.Shutdown(ctx)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vicanso/elton"
+	"github.com/vicanso/elton/middleware"
+)
+
+const (
+	// defaultWarmConcurrency caps how many files are compressed at once so
+	// warming a large tree doesn't saturate the CPU with brotli work.
+	defaultWarmConcurrency = 4
+	// defaultWarmMaxSize skips pre-warming anything larger than this; big
+	// files are cheap enough to compress lazily on first request.
+	defaultWarmMaxSize = 10 * 1024 * 1024
+)
+
+// warmCache walks staticPath once and issues a synthetic GET for every
+// eligible file through e, so the cache+compressor chain is hot before any
+// real client arrives. denyDot mirrors middleware.StaticServeConfig.DenyDot.
+func warmCache(e *elton.Elton, staticPath string, denyDot bool, concurrency int) {
+	if concurrency <= 0 {
+		concurrency = defaultWarmConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	_ = filepath.Walk(staticPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info == nil || info.IsDir() {
+			return nil
+		}
+		name := info.Name()
+		if denyDot && strings.HasPrefix(name, ".") {
+			return nil
+		}
+		if info.Size() > defaultWarmMaxSize {
+			return nil
+		}
+		rel, err := filepath.Rel(staticPath, path)
+		if err != nil {
+			return nil
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(urlPath string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			req := httptest.NewRequest(http.MethodGet, "/"+filepath.ToSlash(urlPath), nil)
+			req.Header.Set("Accept-Encoding", "br")
+			e.ServeHTTP(httptest.NewRecorder(), req)
+		}(rel)
+		return nil
+	})
+	wg.Wait()
+}
+
+// startWarmer launches a goroutine that runs warmCache immediately and then
+// every interval until the process exits, keeping the LRU hot after deploys
+// that change many files. It returns without blocking so a large static
+// tree never delays server startup.
+func startWarmer(e *elton.Elton, staticPath string, denyDot bool, interval time.Duration, concurrency int) {
+	go func() {
+		log.Println("warming static cache for", staticPath)
+		warmCache(e, staticPath, denyDot, concurrency)
+		if interval <= 0 {
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			warmCache(e, staticPath, denyDot, concurrency)
+		}
+	}()
+}
+
+// mtimeHeaderSize is the width, in bytes, of the mtime stamp mtimeCacheStore
+// prepends to every value it stores.
+const mtimeHeaderSize = 8
+
+// mtimeCacheStore wraps a middleware.CacheStore and discards a cached entry
+// on Get if the underlying file's mtime has moved on since it was Set, so
+// edits propagate immediately instead of waiting out cacheTTL. The mtime is
+// stamped onto the stored payload itself (rather than tracked in a parallel
+// map) so its lifetime is tied to whatever eviction policy the wrapped
+// store already has -- an entry the LRU has evicted can't linger forever.
+// It assumes the default cache key format ("METHOD RequestURI"), matching
+// the cache middleware's cacheDefaultGetKey since main.go never overrides
+// GetKey.
+type mtimeCacheStore struct {
+	middleware.CacheStore
+	staticPath string
+}
+
+// newMtimeCacheStore wraps store so that a request for a file whose mtime
+// has changed since it was cached is treated as a cache miss, forcing the
+// cache middleware to fetch and re-store a fresh copy.
+func newMtimeCacheStore(store middleware.CacheStore, staticPath string) *mtimeCacheStore {
+	return &mtimeCacheStore{CacheStore: store, staticPath: staticPath}
+}
+
+func (s *mtimeCacheStore) filePath(key string) string {
+	_, uri, found := strings.Cut(key, " ")
+	if !found {
+		return ""
+	}
+	if i := strings.IndexByte(uri, '?'); i != -1 {
+		uri = uri[:i]
+	}
+	return filepath.Join(s.staticPath, strings.TrimPrefix(uri, "/"))
+}
+
+func (s *mtimeCacheStore) Get(ctx context.Context, key string) ([]byte, error) {
+	raw, err := s.CacheStore.Get(ctx, key)
+	if err != nil || len(raw) < mtimeHeaderSize {
+		return raw, err
+	}
+	storedMtime := int64(binary.BigEndian.Uint64(raw))
+	data := raw[mtimeHeaderSize:]
+	info, statErr := os.Stat(s.filePath(key))
+	if statErr == nil && info.ModTime().UnixNano() != storedMtime {
+		return nil, nil
+	}
+	return data, nil
+}
+
+func (s *mtimeCacheStore) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	var mtime int64
+	if info, err := os.Stat(s.filePath(key)); err == nil {
+		mtime = info.ModTime().UnixNano()
+	}
+	buf := make([]byte, mtimeHeaderSize+len(data))
+	binary.BigEndian.PutUint64(buf, uint64(mtime))
+	copy(buf[mtimeHeaderSize:], data)
+	return s.CacheStore.Set(ctx, key, buf, ttl)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// memCacheStore is a trivial in-memory middleware.CacheStore for testing
+// mtimeCacheStore's own Get/Set logic in isolation.
+type memCacheStore struct {
+	data map[string][]byte
+}
+
+func (m *memCacheStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return m.data[key], nil
+}
+
+func (m *memCacheStore) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	if m.data == nil {
+		m.data = map[string][]byte{}
+	}
+	m.data[key] = data
+	return nil
+}
+
+func TestMtimeCacheStoreInvalidatesOnChange(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(file, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newMtimeCacheStore(&memCacheStore{}, dir)
+	ctx := context.Background()
+	key := "GET /a.txt"
+
+	if err := store.Set(ctx, key, []byte("cached"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "cached" {
+		t.Fatalf("Get() = %q, want %q", got, "cached")
+	}
+
+	// mtime unchanged: still a hit
+	got, err = store.Get(ctx, key)
+	if err != nil || string(got) != "cached" {
+		t.Fatalf("Get() = %q, %v, want hit", got, err)
+	}
+
+	// bump mtime, as a deploy overwriting the file would
+	newMtime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(file, newMtime, newMtime); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = store.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Fatalf("Get() after mtime change = %q, want nil (miss)", got)
+	}
+}
+
+func TestMtimeCacheStoreFilePath(t *testing.T) {
+	store := newMtimeCacheStore(&memCacheStore{}, "/static")
+	if got, want := store.filePath("GET /a.txt?v=1"), filepath.Join("/static", "a.txt"); got != want {
+		t.Errorf("filePath() = %q, want %q", got, want)
+	}
+}